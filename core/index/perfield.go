@@ -110,7 +110,8 @@ func (w *PerFieldPostingsWriter) addField(field *model.FieldInfo) (TermsConsumer
 		consumer.suffix = suffix
 		w.formats[format] = consumer
 	} else {
-		panic("not implemented yet")
+		// Already seen this format, so just grab its suffix
+		suffix = consumer.suffix
 	}
 
 	previousValue = field.PutAttribute(PER_FIELD_SUFFIX_KEY, fmt.Sprintf("%v", suffix))
@@ -244,18 +245,136 @@ containing the format name. For example, in a per-field configuration
 instead of _1.dat fielnames would look like _1_Lucene40_0.dat.
 */
 type PerFieldDocValuesFormat struct {
+	docValuesFormatForField func(string) DocValuesFormat
 }
 
 func newPerFieldDocValuesFormat(f func(field string) DocValuesFormat) *PerFieldDocValuesFormat {
-	return &PerFieldDocValuesFormat{}
+	return &PerFieldDocValuesFormat{f}
 }
 
 func (pf *PerFieldDocValuesFormat) Name() string {
 	return "PerFieldDV40"
 }
 
-func (pf *PerFieldDocValuesFormat) FieldsConsumer(state *model.SegmentWriteState) (w DocValuesConsumer, err error) {
-	panic("not implemented yet")
+func (pf *PerFieldDocValuesFormat) FieldsConsumer(state *model.SegmentWriteState) (DocValuesConsumer, error) {
+	return newPerFieldDocValuesWriter(pf, state), nil
+}
+
+type DocValuesConsumerAndSuffix struct {
+	consumer DocValuesConsumer
+	suffix   int
+}
+
+func (dcas *DocValuesConsumerAndSuffix) Close() error {
+	return dcas.consumer.Close()
+}
+
+type PerFieldDocValuesWriter struct {
+	owner             *PerFieldDocValuesFormat
+	formats           map[DocValuesFormat]*DocValuesConsumerAndSuffix
+	suffixes          map[string]int
+	segmentWriteState *model.SegmentWriteState
+}
+
+func newPerFieldDocValuesWriter(owner *PerFieldDocValuesFormat,
+	state *model.SegmentWriteState) DocValuesConsumer {
+	return &PerFieldDocValuesWriter{
+		owner,
+		make(map[DocValuesFormat]*DocValuesConsumerAndSuffix),
+		make(map[string]int),
+		state,
+	}
+}
+
+/*
+Returns the [possibly newly created] consumer for the given field's
+DocValuesFormat, stamping the format/suffix attributes on the
+FieldInfo on every call; each FieldInfo may be routed only once.
+*/
+func (w *PerFieldDocValuesWriter) consumerFor(field *model.FieldInfo) (*DocValuesConsumerAndSuffix, error) {
+	format := w.owner.docValuesFormatForField(field.Name)
+	assert2(format != nil, "invalid nil DocValuesFormat for field='%v'", field.Name)
+	formatName := format.Name()
+
+	previousValue := field.PutAttribute(PER_FIELD_FORMAT_KEY, formatName)
+	assert(previousValue == "")
+
+	var suffix int
+
+	consumer, ok := w.formats[format]
+	if !ok {
+		// First time we are seeing this format; create a new instance
+
+		// bump the suffix
+		if suffix, ok = w.suffixes[formatName]; !ok {
+			suffix = 0
+		} else {
+			suffix = suffix + 1
+		}
+		w.suffixes[formatName] = suffix
+
+		segmentSuffix := fullSegmentSuffix(field.Name,
+			w.segmentWriteState.SegmentSuffix,
+			_suffix(formatName, strconv.Itoa(suffix)))
+
+		consumer = new(DocValuesConsumerAndSuffix)
+		var err error
+		consumer.consumer, err = format.FieldsConsumer(
+			model.NewSegmentWriteStateFrom(w.segmentWriteState, segmentSuffix))
+		if err != nil {
+			return nil, err
+		}
+		consumer.suffix = suffix
+		w.formats[format] = consumer
+	} else {
+		// Already seen this format, so just grab its suffix
+		suffix = consumer.suffix
+	}
+
+	previousValue = field.PutAttribute(PER_FIELD_SUFFIX_KEY, fmt.Sprintf("%v", suffix))
+	assert(previousValue == "")
+
+	return consumer, nil
+}
+
+func (w *PerFieldDocValuesWriter) AddNumericField(field *model.FieldInfo, values []int64) error {
+	consumer, err := w.consumerFor(field)
+	if err != nil {
+		return err
+	}
+	return consumer.consumer.AddNumericField(field, values)
+}
+
+func (w *PerFieldDocValuesWriter) AddBinaryField(field *model.FieldInfo, values [][]byte) error {
+	consumer, err := w.consumerFor(field)
+	if err != nil {
+		return err
+	}
+	return consumer.consumer.AddBinaryField(field, values)
+}
+
+func (w *PerFieldDocValuesWriter) AddSortedField(field *model.FieldInfo, values [][]byte, docToOrd []int64) error {
+	consumer, err := w.consumerFor(field)
+	if err != nil {
+		return err
+	}
+	return consumer.consumer.AddSortedField(field, values, docToOrd)
+}
+
+func (w *PerFieldDocValuesWriter) AddSortedSetField(field *model.FieldInfo, values [][]byte, docToOrdCount, ords []int64) error {
+	consumer, err := w.consumerFor(field)
+	if err != nil {
+		return err
+	}
+	return consumer.consumer.AddSortedSetField(field, values, docToOrdCount, ords)
+}
+
+func (w *PerFieldDocValuesWriter) Close() error {
+	var subs []io.Closer
+	for _, v := range w.formats {
+		subs = append(subs, v)
+	}
+	return util.Close(subs...)
 }
 
 func (pf *PerFieldDocValuesFormat) FieldsProducer(state SegmentReadState) (r DocValuesProducer, err error) {
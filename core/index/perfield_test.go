@@ -0,0 +1,97 @@
+package index
+
+import (
+	"github.com/balzaczyy/golucene/core/index/model"
+	"testing"
+)
+
+type mockFieldsConsumer struct {
+	closed bool
+}
+
+func (m *mockFieldsConsumer) addField(field *model.FieldInfo) (TermsConsumer, error) {
+	return nil, nil
+}
+
+func (m *mockFieldsConsumer) Close() error {
+	m.closed = true
+	return nil
+}
+
+type mockPostingsFormat struct {
+	name  string
+	calls int
+}
+
+func (pf *mockPostingsFormat) Name() string { return pf.name }
+
+func (pf *mockPostingsFormat) FieldsConsumer(state *model.SegmentWriteState) (FieldsConsumer, error) {
+	pf.calls++
+	return &mockFieldsConsumer{}, nil
+}
+
+func (pf *mockPostingsFormat) FieldsProducer(state SegmentReadState) (FieldsProducer, error) {
+	return nil, nil
+}
+
+func newTestPerFieldPostingsWriter(forField func(string) PostingsFormat) *PerFieldPostingsWriter {
+	owner := newPerFieldPostingsFormat(forField)
+	state := &model.SegmentWriteState{SegmentSuffix: ""}
+	return newPerFieldPostingsWriter(owner, state).(*PerFieldPostingsWriter)
+}
+
+func TestPerFieldPostingsWriterSharesFormat(t *testing.T) {
+	pf := &mockPostingsFormat{name: "Mock40"}
+	w := newTestPerFieldPostingsWriter(func(field string) PostingsFormat {
+		return pf
+	})
+
+	f1 := &model.FieldInfo{Name: "field1"}
+	f2 := &model.FieldInfo{Name: "field2"}
+
+	if _, err := w.addField(f1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.addField(f2); err != nil {
+		t.Fatal(err)
+	}
+
+	if pf.calls != 1 {
+		t.Errorf("expected a single underlying FieldsConsumer to be created, got %v", pf.calls)
+	}
+	if f1.Attribute(PER_FIELD_SUFFIX_KEY) != f2.Attribute(PER_FIELD_SUFFIX_KEY) {
+		t.Errorf("expected fields sharing a format to also share a suffix")
+	}
+}
+
+func TestPerFieldPostingsWriterSeparatesFormats(t *testing.T) {
+	pf1 := &mockPostingsFormat{name: "Mock40"}
+	pf2 := &mockPostingsFormat{name: "Mock41"}
+	w := newTestPerFieldPostingsWriter(func(field string) PostingsFormat {
+		if field == "field1" {
+			return pf1
+		}
+		return pf2
+	})
+
+	f1 := &model.FieldInfo{Name: "field1"}
+	f2 := &model.FieldInfo{Name: "field2"}
+
+	if _, err := w.addField(f1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.addField(f2); err != nil {
+		t.Fatal(err)
+	}
+
+	if pf1.calls != 1 || pf2.calls != 1 {
+		t.Errorf("expected each format to get its own FieldsConsumer, got %v/%v", pf1.calls, pf2.calls)
+	}
+	// Suffixes are numbered per format name, so the format name plus
+	// suffix is what keeps the two formats' files apart.
+	s1 := f1.Attribute(PER_FIELD_FORMAT_KEY) + "_" + f1.Attribute(PER_FIELD_SUFFIX_KEY)
+	s2 := f2.Attribute(PER_FIELD_FORMAT_KEY) + "_" + f2.Attribute(PER_FIELD_SUFFIX_KEY)
+	if s1 == s2 {
+		t.Errorf("expected fields with different formats to get distinct segment suffixes, got %v/%v", s1, s2)
+	}
+}
@@ -19,6 +19,18 @@ type SegmentCommitInfo struct {
 	// Normally 1+delGen, unless an exception was hit on last attempt to write:
 	nextWriteDelGen int64
 
+	// Generation number of the FieldInfos file (-1 if there are no
+	// updates to the FieldInfos yet)
+	fieldInfosGen int64
+	// Normally 1+fieldInfosGen, unless an exception was hit on last attempt to write:
+	nextWriteFieldInfosGen int64
+
+	// Generation number of the DocValues file (-1 if there are no
+	// updates to DocValues yet)
+	docValuesGen int64
+	// Normally 1+docValuesGen, unless an exception was hit on last attempt to write:
+	nextWriteDocValuesGen int64
+
 	sizeInBytes int64 // volatile
 
 	// NOTE: only used by in-RAM by IW to track buffered deletes;
@@ -29,21 +41,54 @@ type SegmentCommitInfo struct {
 func NewSegmentCommitInfo(info *model.SegmentInfo,
 	delCount int, delGen, fieldInfosGen, docValuesGen int64) *SegmentCommitInfo {
 
-	panic("not implemented yet")
-
 	nextWriteDelGen := int64(1)
 	if delGen != -1 {
 		nextWriteDelGen = delGen + 1
 	}
+
+	nextWriteFieldInfosGen := int64(1)
+	if fieldInfosGen != -1 {
+		nextWriteFieldInfosGen = fieldInfosGen + 1
+	}
+
+	nextWriteDocValuesGen := int64(1)
+	if docValuesGen != -1 {
+		nextWriteDocValuesGen = docValuesGen + 1
+	}
+
 	return &SegmentCommitInfo{
-		info:            info,
-		delCount:        delCount,
-		delGen:          delGen,
-		nextWriteDelGen: nextWriteDelGen,
-		sizeInBytes:     -1,
+		info:                   info,
+		delCount:               delCount,
+		delGen:                 delGen,
+		nextWriteDelGen:        nextWriteDelGen,
+		fieldInfosGen:          fieldInfosGen,
+		nextWriteFieldInfosGen: nextWriteFieldInfosGen,
+		docValuesGen:           docValuesGen,
+		nextWriteDocValuesGen:  nextWriteDocValuesGen,
+		sizeInBytes:            -1,
 	}
 }
 
+// Generation number of the FieldInfos file, or -1 if there are no
+// field info updates yet.
+func (info *SegmentCommitInfo) FieldInfosGen() int64 {
+	return info.fieldInfosGen
+}
+
+// Generation number of the DocValues file, or -1 if there are no
+// DocValues updates yet.
+func (info *SegmentCommitInfo) DocValuesGen() int64 {
+	return info.docValuesGen
+}
+
+func (info *SegmentCommitInfo) NextWriteFieldInfosGen() int64 {
+	return info.nextWriteFieldInfosGen
+}
+
+func (info *SegmentCommitInfo) NextWriteDocValuesGen() int64 {
+	return info.nextWriteDocValuesGen
+}
+
 /* Called when we succeed in writing deletes */
 func (info *SegmentCommitInfo) advanceDelGen() {
 	info.delGen, info.nextWriteDelGen = info.nextWriteDelGen, info.delGen+1
@@ -58,6 +103,20 @@ func (info *SegmentCommitInfo) advanceNextWriteDelGen() {
 	info.nextWriteDelGen++
 }
 
+/* Called when we succeed in writing a new FieldInfos generation. */
+func (info *SegmentCommitInfo) advanceFieldInfosGen() {
+	info.fieldInfosGen = info.nextWriteFieldInfosGen
+	info.nextWriteFieldInfosGen = info.fieldInfosGen + 1
+	info.sizeInBytes = -1
+}
+
+/* Called when we succeed in writing a new DocValues generation. */
+func (info *SegmentCommitInfo) advanceDocValuesGen() {
+	info.docValuesGen = info.nextWriteDocValuesGen
+	info.nextWriteDocValuesGen = info.docValuesGen + 1
+	info.sizeInBytes = -1
+}
+
 /*
 Returns total size in bytes of all files for this segment.
 
@@ -81,7 +140,6 @@ func (si *SegmentCommitInfo) SizeInBytes() (sum int64, err error) {
 
 // Returns all files in use by this segment.
 func (si *SegmentCommitInfo) Files() []string {
-	panic("not implemented yet")
 	// Start from the wrapped info's files:
 	files := make(map[string]bool)
 	for name, _ := range si.info.Files() {
@@ -93,6 +151,17 @@ func (si *SegmentCommitInfo) Files() []string {
 		files[name] = true
 	}
 
+	// Must separately add any FieldInfos updates files
+	if si.fieldInfosGen != -1 {
+		files[genSuffixedFileName(si.info.Name, "fnm", si.fieldInfosGen)] = true
+	}
+
+	// Must separately add any DocValues updates files
+	if si.docValuesGen != -1 {
+		files[genSuffixedFileName(si.info.Name, "dvd", si.docValuesGen)] = true
+		files[genSuffixedFileName(si.info.Name, "dvm", si.docValuesGen)] = true
+	}
+
 	ans := make([]string, 0, len(files))
 	for s, _ := range files {
 		ans = append(ans, s)
@@ -100,6 +169,12 @@ func (si *SegmentCommitInfo) Files() []string {
 	return ans
 }
 
+// Builds the generation-suffixed file name Lucene uses for
+// per-commit updates, e.g. "_1_2.fnm" for generation 2 of segment _1.
+func genSuffixedFileName(base, ext string, gen int64) string {
+	return fmt.Sprintf("%v_%v.%v", base, gen, ext)
+}
+
 func (si *SegmentCommitInfo) setBufferedUpdatesGen(v int64) {
 	si.BufferedUpdatesGen = v
 	si.sizeInBytes = -1
@@ -118,28 +193,35 @@ func (si *SegmentCommitInfo) setDelCount(delCount int) {
 }
 
 func (si *SegmentCommitInfo) StringOf(dir store.Directory, pendingDelCount int) string {
-	panic("not implemented yet")
-	return si.info.StringOf(dir, si.delCount+pendingDelCount)
-}
-
-func (si *SegmentCommitInfo) String() string {
-	panic("not implemented yet")
-	s := si.info.StringOf(si.info.Dir, si.delCount)
+	s := si.info.StringOf(dir, si.delCount+pendingDelCount)
 	if si.delGen != -1 {
 		s = fmt.Sprintf("%v:delGen=%v", s, si.delGen)
 	}
+	if si.fieldInfosGen != -1 {
+		s = fmt.Sprintf("%v:fieldInfosGen=%v", s, si.fieldInfosGen)
+	}
+	if si.docValuesGen != -1 {
+		s = fmt.Sprintf("%v:docValuesGen=%v", s, si.docValuesGen)
+	}
 	return s
 }
 
+func (si *SegmentCommitInfo) String() string {
+	return si.StringOf(si.info.Dir, 0)
+}
+
 func (si *SegmentCommitInfo) Clone() *SegmentCommitInfo {
-	panic("not implemented yet")
 	// Not clear that we need ot carry over nextWriteDelGen (i.e. do we
 	// ever clone after a failed write and before the next successful
 	// write?), but just do it to be safe:
 	return &SegmentCommitInfo{
-		info:            si.info,
-		delCount:        si.delCount,
-		delGen:          si.delGen,
-		nextWriteDelGen: si.nextWriteDelGen,
+		info:                   si.info,
+		delCount:               si.delCount,
+		delGen:                 si.delGen,
+		nextWriteDelGen:        si.nextWriteDelGen,
+		fieldInfosGen:          si.fieldInfosGen,
+		nextWriteFieldInfosGen: si.nextWriteFieldInfosGen,
+		docValuesGen:           si.docValuesGen,
+		nextWriteDocValuesGen:  si.nextWriteDocValuesGen,
 	}
 }
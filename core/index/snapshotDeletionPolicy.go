@@ -0,0 +1,122 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// index/SnapshotDeletionPolicy.java
+
+/*
+SnapshotDeletionPolicy is an IndexDeletionPolicy that keeps only the
+NumSnapshotsToKeep most recent commit points, while letting callers
+pin individual commits via Snapshot() so they survive concurrent
+merges and flushes until Release()d.
+
+This mirrors scorch's numSnapshotsToKeep plus protected-epoch set: on
+each onCommit, persisted commits are sorted by generation descending,
+the newest NumSnapshotsToKeep plus any refcounted generations are
+marked protected, and everything else is deleted.
+*/
+type SnapshotDeletionPolicy struct {
+	sync.Mutex
+
+	// Number of most-recent commit generations to always protect,
+	// independent of any snapshot.
+	NumSnapshotsToKeep int
+
+	// refCounts[gen] > 0 means generation gen must not be deleted.
+	refCounts map[int64]int
+
+	// Most recently seen commit for each generation, so Snapshot() can
+	// resolve "the current commit" to an IndexCommit.
+	commits map[int64]IndexCommit
+}
+
+func NewSnapshotDeletionPolicy(numSnapshotsToKeep int) *SnapshotDeletionPolicy {
+	return &SnapshotDeletionPolicy{
+		NumSnapshotsToKeep: numSnapshotsToKeep,
+		refCounts:          make(map[int64]int),
+		commits:            make(map[int64]IndexCommit),
+	}
+}
+
+func (sdp *SnapshotDeletionPolicy) onInit(commits []IndexCommit) error {
+	return sdp.onCommit(commits)
+}
+
+/*
+onCommit sorts the persisted commits by generation descending, marks
+the NumSnapshotsToKeep newest plus any refcounted generations as
+protected, and deletes the rest. The protected set is consulted
+before any commit is deleted, so a live snapshot always survives.
+*/
+func (sdp *SnapshotDeletionPolicy) onCommit(commits []IndexCommit) error {
+	sdp.Lock()
+	defer sdp.Unlock()
+
+	for _, c := range commits {
+		sdp.commits[c.Generation()] = c
+	}
+
+	sorted := make([]IndexCommit, len(commits))
+	copy(sorted, commits)
+	sort.Sort(sort.Reverse(byGeneration(sorted)))
+
+	for i, c := range sorted {
+		gen := c.Generation()
+		protected := i < sdp.NumSnapshotsToKeep || sdp.refCounts[gen] > 0
+		if !protected {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+			delete(sdp.commits, gen)
+		}
+	}
+	return nil
+}
+
+/*
+Snapshot pins the most recent commit generation so it will not be
+deleted by a future onCommit, even once it falls outside
+NumSnapshotsToKeep. The returned IndexCommit must be passed to
+Release() once the caller no longer needs it.
+*/
+func (sdp *SnapshotDeletionPolicy) Snapshot() (IndexCommit, error) {
+	sdp.Lock()
+	defer sdp.Unlock()
+
+	var latest IndexCommit
+	var latestGen int64 = -1
+	for gen, c := range sdp.commits {
+		if gen > latestGen {
+			latestGen, latest = gen, c
+		}
+	}
+	if latest == nil {
+		return nil, errors.New("index has no commits to snapshot yet")
+	}
+	sdp.refCounts[latestGen]++
+	return latest, nil
+}
+
+// Release a commit previously pinned by Snapshot().
+func (sdp *SnapshotDeletionPolicy) Release(commit IndexCommit) error {
+	sdp.Lock()
+	defer sdp.Unlock()
+
+	gen := commit.Generation()
+	if sdp.refCounts[gen] <= 0 {
+		return fmt.Errorf("commit generation %v is not currently snapshotted", gen)
+	}
+	sdp.refCounts[gen]--
+	return nil
+}
+
+type byGeneration []IndexCommit
+
+func (a byGeneration) Len() int           { return len(a) }
+func (a byGeneration) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byGeneration) Less(i, j int) bool { return a[i].Generation() < a[j].Generation() }
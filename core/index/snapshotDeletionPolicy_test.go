@@ -0,0 +1,63 @@
+package index
+
+import "testing"
+
+type mockIndexCommit struct {
+	gen     int64
+	deleted bool
+}
+
+func (c *mockIndexCommit) Generation() int64 { return c.gen }
+
+func (c *mockIndexCommit) Delete() error {
+	c.deleted = true
+	return nil
+}
+
+func TestSnapshotDeletionPolicySurvivesSubsequentCommits(t *testing.T) {
+	sdp := NewSnapshotDeletionPolicy(1)
+
+	c1 := &mockIndexCommit{gen: 1}
+	if err := sdp.onCommit([]IndexCommit{c1}); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := sdp.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Generation() != 1 {
+		t.Fatalf("expected snapshot of generation 1, got %v", snapshot.Generation())
+	}
+
+	// N+1 commits happen after the snapshot was taken; generation 1
+	// falls out of NumSnapshotsToKeep but must stay alive because it
+	// is refcounted.
+	c2 := &mockIndexCommit{gen: 2}
+	if err := sdp.onCommit([]IndexCommit{c1, c2}); err != nil {
+		t.Fatal(err)
+	}
+	c3 := &mockIndexCommit{gen: 3}
+	if err := sdp.onCommit([]IndexCommit{c1, c2, c3}); err != nil {
+		t.Fatal(err)
+	}
+
+	if c1.deleted {
+		t.Errorf("snapshotted commit generation 1 must not be deleted while the snapshot is held")
+	}
+	if !c2.deleted {
+		t.Errorf("expected superseded, non-snapshotted generation 2 to be deleted")
+	}
+
+	if err := sdp.Release(snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	c4 := &mockIndexCommit{gen: 4}
+	if err := sdp.onCommit([]IndexCommit{c1, c3, c4}); err != nil {
+		t.Fatal(err)
+	}
+	if !c1.deleted {
+		t.Errorf("expected generation 1 to be deleted once its snapshot was released")
+	}
+}